@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statusPingInterval is how often /events sends a keep-alive comment so
+// intermediate proxies don't time out the connection while nothing has
+// changed.
+const statusPingInterval = 15 * time.Second
+
+// StatusBroker polls every configured SABnzbd instance on a fixed interval
+// and fans the combined result out to any number of subscribers, so N
+// browser tabs cost each instance one poll instead of N.
+type StatusBroker struct {
+	clients  map[string]*SabnzbdClient
+	interval time.Duration
+	debug    bool
+
+	mu   sync.RWMutex
+	last map[string]*SabnzbdStatus
+
+	subMu       sync.Mutex
+	subscribers map[chan map[string]*SabnzbdStatus]struct{}
+
+	subscriberCount int64
+}
+
+// NewStatusBroker creates a broker that polls every client every interval.
+func NewStatusBroker(clients map[string]*SabnzbdClient, interval time.Duration, debug bool) *StatusBroker {
+	return &StatusBroker{
+		clients:     clients,
+		interval:    interval,
+		debug:       debug,
+		subscribers: make(map[chan map[string]*SabnzbdStatus]struct{}),
+	}
+}
+
+// Run polls SABnzbd on the broker's interval until ctx is canceled. It's
+// meant to be started once, in its own goroutine, for the lifetime of the
+// process.
+func (b *StatusBroker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	b.poll(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.poll(ctx)
+		}
+	}
+}
+
+func (b *StatusBroker) poll(ctx context.Context) {
+	statuses := fetchAll(ctx, b.clients)
+
+	for name, status := range statuses {
+		recordSabnzbdMetrics(name, status)
+	}
+
+	b.mu.Lock()
+	b.last = statuses
+	b.mu.Unlock()
+
+	b.broadcast(statuses)
+}
+
+// Latest returns the most recently polled status for every instance, or nil
+// if no poll has completed yet.
+func (b *StatusBroker) Latest() map[string]*SabnzbdStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.last
+}
+
+// SubscriberCount returns how many /events clients are currently attached.
+func (b *StatusBroker) SubscriberCount() int {
+	return int(atomic.LoadInt64(&b.subscriberCount))
+}
+
+// Subscribe registers a new subscriber and returns a channel that receives
+// every status update, plus an unsubscribe function the caller must call
+// when it's done (typically via defer). The channel is buffered by one and
+// updates are coalesced: a slow subscriber just sees the latest status next
+// time it reads, it never blocks the broker.
+func (b *StatusBroker) Subscribe() (ch chan map[string]*SabnzbdStatus, unsubscribe func()) {
+	ch = make(chan map[string]*SabnzbdStatus, 1)
+
+	b.subMu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.subMu.Unlock()
+	atomic.AddInt64(&b.subscriberCount, 1)
+
+	unsubscribe = func() {
+		b.subMu.Lock()
+		delete(b.subscribers, ch)
+		b.subMu.Unlock()
+		atomic.AddInt64(&b.subscriberCount, -1)
+	}
+	return ch, unsubscribe
+}
+
+// broadcast sends statuses to every subscriber without blocking: if a
+// subscriber's buffer is still full from the previous update, the stale
+// frame is dropped in favor of the new one.
+func (b *StatusBroker) broadcast(statuses map[string]*SabnzbdStatus) {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- statuses:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- statuses:
+			default:
+			}
+		}
+	}
+}
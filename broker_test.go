@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatusBrokerPollFansOutToSubscribers(t *testing.T) {
+	mockServer := mockSabnzbdAPI()
+	defer mockServer.Close()
+
+	client := NewSabnzbdClientForInstance(InstanceConfig{Name: "home", URL: mockServer.URL, APIKey: "test-api-key"}, false, http.DefaultClient)
+	broker := NewStatusBroker(map[string]*SabnzbdClient{"home": client}, time.Hour, false)
+
+	ch, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	if broker.SubscriberCount() != 1 {
+		t.Fatalf("Expected 1 subscriber, got %d", broker.SubscriberCount())
+	}
+
+	broker.poll(context.Background())
+
+	select {
+	case statuses := <-ch:
+		status, ok := statuses["home"]
+		if !ok {
+			t.Fatalf("Expected a status for instance %q, got %+v", "home", statuses)
+		}
+		if status.Queue.Status != "Downloading" {
+			t.Errorf("Expected queue status 'Downloading', got '%s'", status.Queue.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a status update on the subscriber channel")
+	}
+
+	if broker.Latest() == nil {
+		t.Error("Expected Latest() to return the polled status")
+	}
+
+	unsubscribe()
+	if broker.SubscriberCount() != 0 {
+		t.Errorf("Expected 0 subscribers after unsubscribe, got %d", broker.SubscriberCount())
+	}
+}
+
+func TestStatusBrokerCoalescesSlowSubscriber(t *testing.T) {
+	mockServer := mockSabnzbdAPI()
+	defer mockServer.Close()
+
+	client := NewSabnzbdClientForInstance(InstanceConfig{Name: "home", URL: mockServer.URL, APIKey: "test-api-key"}, false, http.DefaultClient)
+	broker := NewStatusBroker(map[string]*SabnzbdClient{"home": client}, time.Hour, false)
+
+	ch, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	// Poll twice without draining the channel; the second update should
+	// replace the first instead of blocking.
+	done := make(chan struct{})
+	go func() {
+		broker.poll(context.Background())
+		broker.poll(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast blocked on a slow subscriber instead of coalescing")
+	}
+
+	select {
+	case <-ch:
+	default:
+		t.Fatal("Expected a coalesced update to be waiting on the channel")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CSRF protection, modeled on Syncthing's GUI: a random token is minted for
+// a browser session on first load, handed back as both a response header
+// and a cookie, and then must be echoed back in a request header on every
+// state-changing call. Tokens are kept in a small in-memory LRU so a long
+// -running server doesn't accumulate one entry per visitor forever.
+const (
+	csrfCookieName    = "sabmon_csrf"
+	sessionCookieName = "sabmon_sid"
+	csrfTokenTTL      = 24 * time.Hour
+	csrfMaxSessions   = 10000
+)
+
+// csrfEntry is the LRU payload for one session's current token.
+type csrfEntry struct {
+	session string
+	token   string
+	expiry  time.Time
+}
+
+// csrfManager issues and validates per-session CSRF tokens with a rolling
+// expiry: every successful validation pushes the expiry another csrfTokenTTL
+// into the future, so active sessions never get logged out mid-use.
+type csrfManager struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // most-recently-used at the front
+	index    map[string]*list.Element // session -> element in order
+}
+
+func newCsrfManager(capacity int) *csrfManager {
+	return &csrfManager{
+		capacity: capacity,
+		order:    list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+// newCsrfToken mints a fresh token for session, evicting the
+// least-recently-used session if the manager is at capacity.
+func (m *csrfManager) newCsrfToken(session string) string {
+	token := randomToken()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.index[session]; ok {
+		el.Value.(*csrfEntry).token = token
+		el.Value.(*csrfEntry).expiry = time.Now().Add(csrfTokenTTL)
+		m.order.MoveToFront(el)
+		return token
+	}
+
+	el := m.order.PushFront(&csrfEntry{session: session, token: token, expiry: time.Now().Add(csrfTokenTTL)})
+	m.index[session] = el
+
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		if oldest == nil {
+			break
+		}
+		m.order.Remove(oldest)
+		delete(m.index, oldest.Value.(*csrfEntry).session)
+	}
+
+	return token
+}
+
+// validCsrfToken reports whether token is the current, unexpired token for
+// session. A successful check refreshes the session's expiry.
+func (m *csrfManager) validCsrfToken(session, token string) bool {
+	if session == "" || token == "" {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.index[session]
+	if !ok {
+		return false
+	}
+	entry := el.Value.(*csrfEntry)
+
+	if time.Now().After(entry.expiry) {
+		m.order.Remove(el)
+		delete(m.index, session)
+		return false
+	}
+	if entry.token != token {
+		return false
+	}
+
+	entry.expiry = time.Now().Add(csrfTokenTTL)
+	m.order.MoveToFront(el)
+	return true
+}
+
+// currentCsrfToken returns session's current token without minting a new
+// one, so callers can tell whether issuing is actually necessary. The
+// second return value is false if the session has no token yet or its
+// token has expired (in which case the expired entry is evicted, same as
+// validCsrfToken).
+func (m *csrfManager) currentCsrfToken(session string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.index[session]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*csrfEntry)
+
+	if time.Now().After(entry.expiry) {
+		m.order.Remove(el)
+		delete(m.index, session)
+		return "", false
+	}
+
+	return entry.token, true
+}
+
+func randomToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand failing means the system RNG is broken; there's no
+		// sane fallback for a security token, so fail loudly.
+		panic(fmt.Sprintf("csrf: failed to read random bytes: %v", err))
+	}
+	return base64.URLEncoding.EncodeToString(buf)
+}
+
+// issueCsrfToken ensures the request has a session cookie and sends its
+// current CSRF token back as both a response header and a cookie, as
+// Syncthing's GUI does on its index page. A new token is only minted on
+// the session's first visit (or once its previous one has expired); every
+// other GET just re-sends the existing token, so reloading the page in one
+// tab doesn't invalidate a token another tab is still holding.
+func issueCsrfToken(csrf *csrfManager, w http.ResponseWriter, r *http.Request) {
+	session := sessionID(r)
+	if _, err := r.Cookie(sessionCookieName); err != nil {
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookieName,
+			Value:    session,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+			MaxAge:   int(csrfTokenTTL.Seconds()),
+		})
+	}
+
+	token, ok := csrf.currentCsrfToken(session)
+	if !ok {
+		token = csrf.newCsrfToken(session)
+	}
+	w.Header().Set("X-CSRF-Token", token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(csrfTokenTTL.Seconds()),
+	})
+}
+
+// sessionID returns the session cookie's value, minting a new random one if
+// the request doesn't already carry one.
+func sessionID(r *http.Request) string {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return randomToken()
+}
+
+// requireCsrf wraps a state-changing handler so it 1) only accepts POST and
+// 2) requires a valid X-CSRF-Token header matching the caller's session.
+func requireCsrf(csrf *csrfManager, config Config, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil {
+			debugLog(config.Debug, "ERROR", "CSRF check failed: missing session cookie", r, config.LogClientInfo)
+			http.Error(w, "CSRF token required", http.StatusForbidden)
+			return
+		}
+
+		token := r.Header.Get("X-CSRF-Token")
+		if !csrf.validCsrfToken(cookie.Value, token) {
+			debugLog(config.Debug, "ERROR", "CSRF check failed: invalid or expired token", r, config.LogClientInfo)
+			http.Error(w, "Invalid or expired CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
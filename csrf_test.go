@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCsrfTokenValidAfterIssue(t *testing.T) {
+	csrf := newCsrfManager(10)
+
+	token := csrf.newCsrfToken("session-1")
+
+	if !csrf.validCsrfToken("session-1", token) {
+		t.Error("Expected freshly issued token to validate")
+	}
+}
+
+func TestCsrfTokenRejectsWrongTokenOrSession(t *testing.T) {
+	csrf := newCsrfManager(10)
+	token := csrf.newCsrfToken("session-1")
+
+	if csrf.validCsrfToken("session-1", "not-the-token") {
+		t.Error("Expected mismatched token to be rejected")
+	}
+
+	if csrf.validCsrfToken("session-2", token) {
+		t.Error("Expected token issued to a different session to be rejected")
+	}
+}
+
+func TestCsrfTokenExpiresAfterTTL(t *testing.T) {
+	csrf := newCsrfManager(10)
+	token := csrf.newCsrfToken("session-1")
+
+	// Force the entry into the past instead of sleeping 24h.
+	el := csrf.index["session-1"]
+	el.Value.(*csrfEntry).expiry = time.Now().Add(-time.Minute)
+
+	if csrf.validCsrfToken("session-1", token) {
+		t.Error("Expected expired token to be rejected")
+	}
+
+	if _, ok := csrf.index["session-1"]; ok {
+		t.Error("Expected expired session to be evicted from the index")
+	}
+}
+
+func TestCsrfTokenEvictsLeastRecentlyUsedAfterManyIssuances(t *testing.T) {
+	const capacity = 5
+	csrf := newCsrfManager(capacity)
+
+	var tokens [capacity]string
+	for i := 0; i < capacity; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		tokens[i] = csrf.newCsrfToken(session)
+	}
+
+	// Issuing tokens for many more sessions should evict the oldest ones.
+	for i := capacity; i < capacity*20; i++ {
+		csrf.newCsrfToken(fmt.Sprintf("session-%d", i))
+	}
+
+	if csrf.order.Len() != capacity {
+		t.Fatalf("Expected LRU to hold exactly %d entries, got %d", capacity, csrf.order.Len())
+	}
+
+	if csrf.validCsrfToken("session-0", tokens[0]) {
+		t.Error("Expected the earliest session to have been evicted after many issuances")
+	}
+}
+
+func TestCsrfTokenRollingExpiryOnValidation(t *testing.T) {
+	csrf := newCsrfManager(10)
+	token := csrf.newCsrfToken("session-1")
+
+	el := csrf.index["session-1"]
+	el.Value.(*csrfEntry).expiry = time.Now().Add(time.Second)
+
+	if !csrf.validCsrfToken("session-1", token) {
+		t.Fatal("Expected token nearing expiry to still validate")
+	}
+
+	refreshed := csrf.index["session-1"].Value.(*csrfEntry).expiry
+	if refreshed.Before(time.Now().Add(time.Hour)) {
+		t.Error("Expected a successful validation to push the expiry forward")
+	}
+}
+
+func TestIssueCsrfTokenReusesExistingTokenAcrossRequests(t *testing.T) {
+	csrf := newCsrfManager(10)
+
+	r1 := httptest.NewRequest("GET", "/", nil)
+	w1 := httptest.NewRecorder()
+	issueCsrfToken(csrf, w1, r1)
+	firstToken := w1.Header().Get("X-CSRF-Token")
+	if firstToken == "" {
+		t.Fatal("Expected a CSRF token to be issued on the first request")
+	}
+
+	// A second GET from the same session (same session cookie) should get
+	// back the same token, not a freshly minted one.
+	r2 := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w1.Result().Cookies() {
+		r2.AddCookie(c)
+	}
+	w2 := httptest.NewRecorder()
+	issueCsrfToken(csrf, w2, r2)
+	secondToken := w2.Header().Get("X-CSRF-Token")
+
+	if secondToken != firstToken {
+		t.Errorf("Expected the session's existing token %q to be reused, got %q", firstToken, secondToken)
+	}
+}
+
+func TestIssueCsrfTokenMintsNewTokenAfterExpiry(t *testing.T) {
+	csrf := newCsrfManager(10)
+
+	r1 := httptest.NewRequest("GET", "/", nil)
+	w1 := httptest.NewRecorder()
+	issueCsrfToken(csrf, w1, r1)
+	firstToken := w1.Header().Get("X-CSRF-Token")
+
+	var session string
+	for _, c := range w1.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			session = c.Value
+		}
+	}
+	csrf.index[session].Value.(*csrfEntry).expiry = time.Now().Add(-time.Minute)
+
+	r2 := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w1.Result().Cookies() {
+		r2.AddCookie(c)
+	}
+	w2 := httptest.NewRecorder()
+	issueCsrfToken(csrf, w2, r2)
+	secondToken := w2.Header().Get("X-CSRF-Token")
+
+	if secondToken == firstToken {
+		t.Error("Expected a new token to be minted after the previous one expired")
+	}
+}
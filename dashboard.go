@@ -0,0 +1,51 @@
+package main
+
+import "sort"
+
+// InstancePanel pairs an instance's display name with its latest status, for
+// rendering one panel per SABnzbd instance.
+type InstancePanel struct {
+	Name   string
+	Status *SabnzbdStatus
+}
+
+// DashboardTotals aggregates numbers across every reachable instance.
+type DashboardTotals struct {
+	SpeedBytesPerSecond float64
+	SizeLeftBytes       float64
+	SlotCount           int
+}
+
+// DashboardData is what gets handed to status.html: one panel per instance,
+// in a stable order, plus a totals row summed across all of them.
+type DashboardData struct {
+	Instances []InstancePanel
+	Totals    DashboardTotals
+}
+
+// buildDashboard turns the broker's per-instance status map into something
+// the template can render deterministically. Instances that failed to
+// fetch (absent from statuses) are omitted rather than shown with zeroes.
+func buildDashboard(statuses map[string]*SabnzbdStatus) DashboardData {
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := DashboardData{Instances: make([]InstancePanel, 0, len(names))}
+	for _, name := range names {
+		status := statuses[name]
+		data.Instances = append(data.Instances, InstancePanel{Name: name, Status: status})
+
+		if speed, err := parseSpeed(status.Queue.Speed); err == nil {
+			data.Totals.SpeedBytesPerSecond += speed
+		}
+		if sizeLeft, err := parseSize(status.Queue.SizeLeft); err == nil {
+			data.Totals.SizeLeftBytes += sizeLeft
+		}
+		data.Totals.SlotCount += len(status.Queue.Slots)
+	}
+
+	return data
+}
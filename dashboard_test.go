@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestBuildDashboardAggregatesTotals(t *testing.T) {
+	statuses := map[string]*SabnzbdStatus{
+		"beta": {Queue: Queue{Speed: "1 MB/s", SizeLeft: "100 MB", Slots: []QueueItem{{Filename: "b.mkv"}}}},
+		"alpha": {Queue: Queue{Speed: "2 MB/s", SizeLeft: "200 MB", Slots: []QueueItem{
+			{Filename: "a1.mkv"}, {Filename: "a2.mkv"},
+		}}},
+	}
+
+	dashboard := buildDashboard(statuses)
+
+	if len(dashboard.Instances) != 2 {
+		t.Fatalf("Expected 2 instance panels, got %d", len(dashboard.Instances))
+	}
+	if dashboard.Instances[0].Name != "alpha" || dashboard.Instances[1].Name != "beta" {
+		t.Errorf("Expected panels sorted by name, got %s then %s", dashboard.Instances[0].Name, dashboard.Instances[1].Name)
+	}
+
+	wantSpeed := 3 * 1000 * 1000.0
+	if dashboard.Totals.SpeedBytesPerSecond != wantSpeed {
+		t.Errorf("Expected combined speed %v, got %v", wantSpeed, dashboard.Totals.SpeedBytesPerSecond)
+	}
+
+	wantSizeLeft := 300 * 1000 * 1000.0
+	if dashboard.Totals.SizeLeftBytes != wantSizeLeft {
+		t.Errorf("Expected combined size left %v, got %v", wantSizeLeft, dashboard.Totals.SizeLeftBytes)
+	}
+
+	if dashboard.Totals.SlotCount != 3 {
+		t.Errorf("Expected 3 total slots, got %d", dashboard.Totals.SlotCount)
+	}
+}
+
+func TestBuildDashboardEmpty(t *testing.T) {
+	dashboard := buildDashboard(nil)
+
+	if len(dashboard.Instances) != 0 {
+		t.Errorf("Expected no instance panels for an empty status map, got %d", len(dashboard.Instances))
+	}
+	if dashboard.Totals.SlotCount != 0 {
+		t.Errorf("Expected zero totals for an empty status map, got %+v", dashboard.Totals)
+	}
+}
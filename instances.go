@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// InstanceConfig describes a single SABnzbd instance to monitor.
+type InstanceConfig struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	APIKey string   `json:"api_key"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Environment variables of the form SABMON_INSTANCE_<NAME>_URL and
+// SABMON_INSTANCE_<NAME>_API_KEY describe additional instances, following
+// the prefixed-variable pattern used by kelseyhightower/envconfig.
+const (
+	envInstancePrefix       = "SABMON_INSTANCE_"
+	envInstanceURLSuffix    = "_URL"
+	envInstanceAPIKeySuffix = "_API_KEY"
+)
+
+// discoverInstancesFromEnv scans environ (typically os.Environ()) for
+// SABMON_INSTANCE_<NAME>_URL / SABMON_INSTANCE_<NAME>_API_KEY pairs and
+// returns the instances they describe, sorted by name for stable ordering.
+func discoverInstancesFromEnv(environ []string) []InstanceConfig {
+	urls := make(map[string]string)
+	apiKeys := make(map[string]string)
+
+	for _, kv := range environ {
+		name, value, ok := splitEnvVar(kv)
+		if !ok || !strings.HasPrefix(name, envInstancePrefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(name, envInstancePrefix)
+		switch {
+		case strings.HasSuffix(rest, envInstanceAPIKeySuffix):
+			apiKeys[strings.TrimSuffix(rest, envInstanceAPIKeySuffix)] = value
+		case strings.HasSuffix(rest, envInstanceURLSuffix):
+			urls[strings.TrimSuffix(rest, envInstanceURLSuffix)] = value
+		}
+	}
+
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	instances := make([]InstanceConfig, 0, len(names))
+	for _, name := range names {
+		instances = append(instances, InstanceConfig{
+			Name:   strings.ToLower(name),
+			URL:    urls[name],
+			APIKey: apiKeys[name],
+		})
+	}
+	return instances
+}
+
+func splitEnvVar(kv string) (name, value string, ok bool) {
+	idx := strings.IndexByte(kv, '=')
+	if idx < 0 {
+		return "", "", false
+	}
+	return kv[:idx], kv[idx+1:], true
+}
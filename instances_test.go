@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverInstancesFromEnv(t *testing.T) {
+	environ := []string{
+		"SABMON_INSTANCE_HOME_URL=http://home:8080",
+		"SABMON_INSTANCE_HOME_API_KEY=home-key",
+		"SABMON_INSTANCE_SEEDBOX_URL=http://seedbox:8080",
+		"SABMON_INSTANCE_SEEDBOX_API_KEY=seedbox-key",
+		"SABMON_DEBUG=true",
+		"PATH=/usr/bin",
+	}
+
+	got := discoverInstancesFromEnv(environ)
+	want := []InstanceConfig{
+		{Name: "home", URL: "http://home:8080", APIKey: "home-key"},
+		{Name: "seedbox", URL: "http://seedbox:8080", APIKey: "seedbox-key"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("discoverInstancesFromEnv() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiscoverInstancesFromEnvIgnoresUnrelatedVars(t *testing.T) {
+	environ := []string{"SABMON_SABNZBD_URL=http://legacy:8080", "HOME=/root"}
+
+	got := discoverInstancesFromEnv(environ)
+	if len(got) != 0 {
+		t.Errorf("Expected no instances from unrelated env vars, got %+v", got)
+	}
+}
+
+func TestDiscoverInstancesFromEnvMissingAPIKey(t *testing.T) {
+	environ := []string{"SABMON_INSTANCE_HOME_URL=http://home:8080"}
+
+	got := discoverInstancesFromEnv(environ)
+	want := []InstanceConfig{{Name: "home", URL: "http://home:8080", APIKey: ""}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("discoverInstancesFromEnv() = %+v, want %+v", got, want)
+	}
+}
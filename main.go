@@ -1,10 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -16,11 +17,33 @@ import (
 
 // Config holds application configuration
 type Config struct {
-	SabnzbdURL      string `json:"sabnzbd_url"`
-	SabnzbdAPIKey   string `json:"sabnzbd_api_key"`
-	RefreshInterval int    `json:"refresh_interval"` // in seconds
-	Debug           bool   `json:"debug"`            // enable debug logging
-	LogClientInfo   bool   `json:"log_client_info"`  // log client IP and user agent
+	// SabnzbdURL and SabnzbdAPIKey describe a single SABnzbd instance.
+	// Deprecated: set Instances instead; LoadConfig folds these into a
+	// single "default" instance for backwards compatibility.
+	SabnzbdURL    string `json:"sabnzbd_url,omitempty"`
+	SabnzbdAPIKey string `json:"sabnzbd_api_key,omitempty"`
+
+	Instances []InstanceConfig `json:"instances"`
+
+	RefreshInterval int  `json:"refresh_interval"` // in seconds
+	Debug           bool `json:"debug"`            // enable debug logging
+	LogClientInfo   bool `json:"log_client_info"`  // log client IP and user agent
+
+	TLSCertFile  string `json:"tls_cert_file"` // path to a PEM certificate, enables HTTPS when set
+	TLSKeyFile   string `json:"tls_key_file"`  // path to the matching PEM private key
+	AutoTLS      bool   `json:"auto_tls"`      // generate and persist a self-signed cert if no cert/key is configured
+	TLSRedirect  bool   `json:"tls_redirect"`  // also listen on RedirectPort and redirect plain HTTP to HTTPS
+	RedirectPort string `json:"redirect_port"` // port for the HTTP->HTTPS redirect listener
+
+	MetricsEnabled bool `json:"metrics_enabled"` // expose /metrics in Prometheus exposition format
+
+	GzipEnabled        bool    `json:"gzip_enabled"`          // compress responses when the client accepts gzip
+	RequestIDEnabled   bool    `json:"request_id_enabled"`    // assign/propagate an X-Request-ID per request
+	RecoverEnabled     bool    `json:"recover_enabled"`       // turn panics into a 500 instead of crashing
+	RateLimitEnabled   bool    `json:"rate_limit_enabled"`    // enable per-IP rate limiting on write/status endpoints
+	RateLimitPerSecond float64 `json:"rate_limit_per_second"` // sustained requests per second per client IP
+	RateLimitBurst     int     `json:"rate_limit_burst"`      // token bucket burst size per client IP
+	TrustProxyHeaders  bool    `json:"trust_proxy_headers"`   // trust X-Forwarded-For for rate limiting; only set this behind a trusted reverse proxy
 }
 
 // Environment variable names
@@ -30,11 +53,31 @@ const (
 	EnvRefreshInterval = "SABMON_REFRESH_INTERVAL"
 	EnvDebug           = "SABMON_DEBUG"
 	EnvLogClientInfo   = "SABMON_LOG_CLIENT_INFO"
+
+	EnvTLSCertFile  = "SABMON_TLS_CERT_FILE"
+	EnvTLSKeyFile   = "SABMON_TLS_KEY_FILE"
+	EnvAutoTLS      = "SABMON_TLS_AUTO"
+	EnvTLSRedirect  = "SABMON_TLS_REDIRECT"
+	EnvRedirectPort = "SABMON_TLS_REDIRECT_PORT"
+
+	EnvMetricsEnabled = "SABMON_METRICS_ENABLED"
+
+	EnvGzipEnabled        = "SABMON_MW_GZIP_ENABLED"
+	EnvRequestIDEnabled   = "SABMON_MW_REQUEST_ID_ENABLED"
+	EnvRecoverEnabled     = "SABMON_MW_RECOVER_ENABLED"
+	EnvRateLimitEnabled   = "SABMON_MW_RATE_LIMIT_ENABLED"
+	EnvRateLimitPerSecond = "SABMON_MW_RATE_LIMIT_PER_SECOND"
+	EnvRateLimitBurst     = "SABMON_MW_RATE_LIMIT_BURST"
+	EnvTrustProxyHeaders  = "SABMON_MW_TRUST_PROXY_HEADERS"
 )
 
 // Application constants
 const (
-	AppPort = "5959" // Fixed application port
+	AppPort             = "5959" // Fixed application port
+	DefaultRedirectPort = "5958" // Fixed HTTP->HTTPS redirect port
+
+	DefaultRateLimitPerSecond = 5.0 // sustained requests per second per client IP
+	DefaultRateLimitBurst     = 10  // token bucket burst size per client IP
 )
 
 // LoadConfig loads configuration from config.json in the current working directory
@@ -86,6 +129,63 @@ func LoadConfig() (Config, error) {
 	if envLogClient := os.Getenv(EnvLogClientInfo); envLogClient != "" {
 		config.LogClientInfo = envLogClient == "1" || strings.ToLower(envLogClient) == "true"
 	}
+	if envCert := os.Getenv(EnvTLSCertFile); envCert != "" {
+		config.TLSCertFile = envCert
+	}
+	if envKey := os.Getenv(EnvTLSKeyFile); envKey != "" {
+		config.TLSKeyFile = envKey
+	}
+	if envAutoTLS := os.Getenv(EnvAutoTLS); envAutoTLS != "" {
+		config.AutoTLS = envAutoTLS == "1" || strings.ToLower(envAutoTLS) == "true"
+	}
+	if envRedirect := os.Getenv(EnvTLSRedirect); envRedirect != "" {
+		config.TLSRedirect = envRedirect == "1" || strings.ToLower(envRedirect) == "true"
+	}
+	if envRedirectPort := os.Getenv(EnvRedirectPort); envRedirectPort != "" {
+		config.RedirectPort = envRedirectPort
+	}
+	if config.RedirectPort == "" {
+		config.RedirectPort = DefaultRedirectPort
+	}
+	if envMetrics := os.Getenv(EnvMetricsEnabled); envMetrics != "" {
+		config.MetricsEnabled = envMetrics == "1" || strings.ToLower(envMetrics) == "true"
+	}
+	if envGzip := os.Getenv(EnvGzipEnabled); envGzip != "" {
+		config.GzipEnabled = envGzip == "1" || strings.ToLower(envGzip) == "true"
+	}
+	if envReqID := os.Getenv(EnvRequestIDEnabled); envReqID != "" {
+		config.RequestIDEnabled = envReqID == "1" || strings.ToLower(envReqID) == "true"
+	}
+	if envRecover := os.Getenv(EnvRecoverEnabled); envRecover != "" {
+		config.RecoverEnabled = envRecover == "1" || strings.ToLower(envRecover) == "true"
+	}
+	if envRateLimit := os.Getenv(EnvRateLimitEnabled); envRateLimit != "" {
+		config.RateLimitEnabled = envRateLimit == "1" || strings.ToLower(envRateLimit) == "true"
+	}
+	if envRPS := os.Getenv(EnvRateLimitPerSecond); envRPS != "" {
+		if val, err := strconv.ParseFloat(envRPS, 64); err == nil {
+			config.RateLimitPerSecond = val
+		} else {
+			log.Printf("Warning: Invalid %s value '%s', must be a number", EnvRateLimitPerSecond, envRPS)
+		}
+	}
+	if envBurst := os.Getenv(EnvRateLimitBurst); envBurst != "" {
+		if val, err := strconv.Atoi(envBurst); err == nil {
+			config.RateLimitBurst = val
+		} else {
+			log.Printf("Warning: Invalid %s value '%s', must be a number", EnvRateLimitBurst, envBurst)
+		}
+	}
+	if config.RateLimitPerSecond <= 0 {
+		config.RateLimitPerSecond = DefaultRateLimitPerSecond
+	}
+	if config.RateLimitBurst <= 0 {
+		config.RateLimitBurst = DefaultRateLimitBurst
+	}
+	if envTrustProxy := os.Getenv(EnvTrustProxyHeaders); envTrustProxy != "" {
+		config.TrustProxyHeaders = envTrustProxy == "1" || strings.ToLower(envTrustProxy) == "true"
+	}
+	config.Instances = append(config.Instances, discoverInstancesFromEnv(os.Environ())...)
 
 	// Validate the configuration
 	if err := validateConfig(&config); err != nil {
@@ -100,16 +200,44 @@ func LoadConfig() (Config, error) {
 	return config, nil
 }
 
-// validateConfig checks if the config has valid values and sets defaults if needed
+// defaultInstanceName is used for the instance synthesized from the
+// deprecated single-instance SabnzbdURL/SabnzbdAPIKey fields.
+const defaultInstanceName = "default"
+
+// validateConfig checks if the config has valid values and sets defaults if
+// needed. It also folds the deprecated single-instance SabnzbdURL/
+// SabnzbdAPIKey fields into Instances, so callers that only set those two
+// fields keep working unchanged.
 func validateConfig(config *Config) error {
-	// SabnzbdURL is required
-	if config.SabnzbdURL == "" {
-		return fmt.Errorf("sabnzbd URL is required (set via config or %s)", EnvSabnzbdURL)
+	if len(config.Instances) == 0 && (config.SabnzbdURL != "" || config.SabnzbdAPIKey != "") {
+		config.Instances = []InstanceConfig{{
+			Name:   defaultInstanceName,
+			URL:    config.SabnzbdURL,
+			APIKey: config.SabnzbdAPIKey,
+		}}
+	}
+
+	if len(config.Instances) == 0 {
+		return fmt.Errorf("at least one SABnzbd instance is required (set via config, %s, or %s*)",
+			EnvSabnzbdURL, envInstancePrefix)
 	}
 
-	// SabnzbdAPIKey is required
-	if config.SabnzbdAPIKey == "" {
-		return fmt.Errorf("sabnzbd API key is required (set via config or %s)", EnvSabnzbdAPIKey)
+	seen := make(map[string]bool, len(config.Instances))
+	for i, instance := range config.Instances {
+		if instance.Name == "" {
+			return fmt.Errorf("instance %d is missing a name", i)
+		}
+		if seen[instance.Name] {
+			return fmt.Errorf("instance %q is configured more than once", instance.Name)
+		}
+		seen[instance.Name] = true
+
+		if instance.URL == "" {
+			return fmt.Errorf("instance %q is missing a URL", instance.Name)
+		}
+		if instance.APIKey == "" {
+			return fmt.Errorf("instance %q is missing an API key", instance.Name)
+		}
 	}
 
 	// RefreshInterval must be greater than 0, default to 5 if invalid
@@ -146,7 +274,11 @@ type QueueItem struct {
 	TimeLeft   string `json:"timeleft"`
 }
 
-// Helper function to get client IP, handling proxies
+// getClientIP returns the caller's IP for display/logging, trusting
+// X-Forwarded-For when present. X-Forwarded-For is client-supplied and
+// trivially spoofed unless a trusted reverse proxy sets it, so this is fine
+// for logging but must not be used to make access-control decisions; see
+// remoteAddrIP for that.
 func getClientIP(r *http.Request) string {
 	// Check for X-Forwarded-For header first
 	forwarded := r.Header.Get("X-Forwarded-For")
@@ -155,7 +287,12 @@ func getClientIP(r *http.Request) string {
 		return strings.Split(forwarded, ",")[0]
 	}
 
-	// Try to get IP from RemoteAddr
+	return remoteAddrIP(r)
+}
+
+// remoteAddrIP returns the IP sab_monitarr's own listener saw the
+// connection come from, ignoring any client-supplied forwarding headers.
+func remoteAddrIP(r *http.Request) string {
 	ip := r.RemoteAddr
 	// Strip port if present
 	if strings.Contains(ip, ":") {
@@ -170,6 +307,12 @@ func debugLog(debug bool, level, message string, r *http.Request, logClientInfo
 		return
 	}
 
+	if r != nil {
+		if reqID := r.Header.Get(RequestIDHeader); reqID != "" {
+			message = fmt.Sprintf("[%s] %s", reqID, message)
+		}
+	}
+
 	log.Printf("[%s] %s", level, message)
 
 	if logClientInfo && r != nil {
@@ -197,15 +340,38 @@ func main() {
 	// Log startup information
 	debugLog(true, "INFO", "Application starting", nil, false)
 	if config.Debug {
-		// Redact sensitive information
+		// Redact every API key (the legacy single-instance one and every
+		// configured instance's) before logging the config.
 		safeConfig := fmt.Sprintf("%+v", config)
-		safeConfig = strings.Replace(safeConfig, config.SabnzbdAPIKey, "[REDACTED]", 1)
+		if config.SabnzbdAPIKey != "" {
+			safeConfig = strings.Replace(safeConfig, config.SabnzbdAPIKey, "[REDACTED]", 1)
+		}
+		for _, instance := range config.Instances {
+			if instance.APIKey != "" {
+				safeConfig = strings.ReplaceAll(safeConfig, instance.APIKey, "[REDACTED]")
+			}
+		}
 		debugLog(true, "INFO", fmt.Sprintf("Configuration: %s", safeConfig), nil, false)
 	}
 
 	// Parse templates
 	tmpl := template.Must(template.ParseFiles("templates/index.html", "templates/status.html"))
 
+	// One SABnzbd API client per configured instance, all sharing a single
+	// http.Client (and its connection pool) instead of opening one per
+	// instance, plus a shared CSRF token manager.
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	clients := make(map[string]*SabnzbdClient, len(config.Instances))
+	for _, instance := range config.Instances {
+		clients[instance.Name] = NewSabnzbdClientForInstance(instance, config.Debug, httpClient)
+	}
+	csrf := newCsrfManager(csrfMaxSessions)
+
+	// Single background poller fanning status out to every /events subscriber,
+	// so RefreshInterval no longer determines how often each instance is hit.
+	broker := NewStatusBroker(clients, time.Duration(config.RefreshInterval)*time.Second, config.Debug)
+	go broker.Run(context.Background())
+
 	// Create a mux for easier middleware use
 	mux := http.NewServeMux()
 
@@ -221,6 +387,7 @@ func main() {
 		}
 
 		debugLog(config.Debug, "INFO", "Serving index page", r, config.LogClientInfo)
+		issueCsrfToken(csrf, w, r)
 
 		tmpl.ExecuteTemplate(w, "index.html", map[string]interface{}{
 			"RefreshInterval": config.RefreshInterval,
@@ -228,72 +395,211 @@ func main() {
 		})
 	})
 
-	// SABnzbd status handler
+	// SABnzbd status handler: aggregates every instance into one dashboard,
+	// used both for the initial page render and as the polling fallback.
+	// Reads the broker's cached statuses instead of fetching fresh, so it
+	// doesn't add an extra round of SABnzbd calls on top of the broker's
+	// own poll; fetchAll only runs directly if the broker hasn't polled yet.
 	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		debugLog(config.Debug, "INFO", "Fetching SABnzbd status", r, config.LogClientInfo)
+		statuses := broker.Latest()
+		if statuses == nil {
+			debugLog(config.Debug, "INFO", "Fetching SABnzbd status", r, config.LogClientInfo)
+			statuses = fetchAll(r.Context(), clients)
+		}
+		debugLog(config.Debug, "INFO", fmt.Sprintf("SABnzbd status served for %d/%d instances", len(statuses), len(clients)), r, config.LogClientInfo)
+		tmpl.ExecuteTemplate(w, "status.html", buildDashboard(statuses))
+	})
+
+	// SABnzbd status stream: pushes updates as the broker polls them instead
+	// of making every client poll /status on its own.
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		serveStatusEvents(broker, tmpl, config, w, r)
+	})
 
-		status, err := fetchSabnzbdStatus(config)
+	// Queue control endpoints. All state-changing, so all CSRF-guarded. Each
+	// takes an "instance" form/query value identifying which SABnzbd
+	// instance to act on; it's optional when only one instance is configured.
+	mux.Handle("/api/queue/pause", requireCsrf(csrf, config, func(w http.ResponseWriter, r *http.Request) {
+		instanceClient, name, err := resolveInstance(clients, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handleQueueAction(w, r, config, fmt.Sprintf("%s/pause", name), func() error {
+			return instanceClient.Pause(r.Context())
+		})
+	}))
+	mux.Handle("/api/queue/resume", requireCsrf(csrf, config, func(w http.ResponseWriter, r *http.Request) {
+		instanceClient, name, err := resolveInstance(clients, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handleQueueAction(w, r, config, fmt.Sprintf("%s/resume", name), func() error {
+			return instanceClient.Resume(r.Context())
+		})
+	}))
+	mux.Handle("/api/queue/speedlimit", requireCsrf(csrf, config, func(w http.ResponseWriter, r *http.Request) {
+		instanceClient, name, err := resolveInstance(clients, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handleQueueAction(w, r, config, fmt.Sprintf("%s/speedlimit", name), func() error {
+			return instanceClient.SetSpeedLimit(r.Context(), r.FormValue("limit"))
+		})
+	}))
+	mux.Handle("/api/queue/slot/", requireCsrf(csrf, config, func(w http.ResponseWriter, r *http.Request) {
+		nzoID, ok := parseSlotDeletePath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		instanceClient, name, err := resolveInstance(clients, r)
 		if err != nil {
-			debugLog(config.Debug, "ERROR", fmt.Sprintf("Failed to fetch status: %v", err), r, config.LogClientInfo)
-			http.Error(w, "Failed to fetch status", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		handleQueueAction(w, r, config, fmt.Sprintf("%s/slot/delete", name), func() error {
+			return instanceClient.DeleteSlot(r.Context(), nzoID)
+		})
+	}))
 
-		debugLog(config.Debug, "INFO", "SABnzbd status fetched successfully", r, config.LogClientInfo)
-		tmpl.ExecuteTemplate(w, "status.html", status)
-	})
+	// Prometheus metrics, gated by config/env so it's opt-in for deployments
+	// that don't want it scraped.
+	if config.MetricsEnabled {
+		mux.Handle("/metrics", metricsHandler())
+	}
 
-	// Apply middleware
-	handler := loggingMiddleware(mux, config.Debug, config.LogClientInfo)
+	// Apply middleware. Built-ins are assembled innermost-first here and
+	// Chain reverses them, so RecoverMiddleware ends up outermost (catches
+	// panics from everything below it) and GzipMiddleware innermost
+	// (closest to the handler writing the response).
+	var builtins []Middleware
+	if config.RecoverEnabled {
+		builtins = append(builtins, RecoverMiddleware)
+	}
+	if config.RequestIDEnabled {
+		builtins = append(builtins, RequestIDMiddleware)
+	}
+	if config.RateLimitEnabled {
+		builtins = append(builtins, RateLimitMiddleware(config.RateLimitPerSecond, config.RateLimitBurst, rateLimitedPaths(), config.TrustProxyHeaders))
+	}
+	if config.GzipEnabled {
+		builtins = append(builtins, GzipMiddleware)
+	}
+	handler := loggingMiddleware(metricsMiddleware(Chain(builtins...).Then(mux)), config.Debug, config.LogClientInfo)
 
-	// Start server
-	log.Printf("Server starting on http://localhost:%s", AppPort)
-	log.Fatal(http.ListenAndServe(":"+AppPort, handler))
-}
+	// Start server, over TLS if a certificate is configured or AutoTLS is enabled
+	certFile, keyFile, useTLS, err := resolveTLSFiles(config)
+	if err != nil {
+		log.Fatalf("TLS setup error: %v", err)
+	}
 
-func fetchSabnzbdStatus(config Config) (*SabnzbdStatus, error) {
-	url := fmt.Sprintf("%s/api?output=json&apikey=%s&mode=queue",
-		config.SabnzbdURL, config.SabnzbdAPIKey)
+	if useTLS {
+		if config.TLSRedirect {
+			go serveRedirect(config)
+		}
+		log.Printf("Server starting on https://localhost:%s", AppPort)
+		log.Fatal(http.ListenAndServeTLS(":"+AppPort, certFile, keyFile, handler))
+	} else {
+		log.Printf("Server starting on http://localhost:%s", AppPort)
+		log.Fatal(http.ListenAndServe(":"+AppPort, handler))
+	}
+}
 
-	if config.Debug {
-		// Don't log the full URL with API key for security reasons
-		safeUrl := strings.Replace(url, config.SabnzbdAPIKey, "[REDACTED]", 1)
-		debugLog(true, "DEBUG", fmt.Sprintf("Requesting SABnzbd API: %s", safeUrl), nil, false)
+// handleQueueAction runs a SABnzbd write action and writes a JSON result,
+// keeping the per-endpoint handlers above to a one-liner each.
+func handleQueueAction(w http.ResponseWriter, r *http.Request, config Config, name string, action func() error) {
+	if err := action(); err != nil {
+		debugLog(config.Debug, "ERROR", fmt.Sprintf("Queue action %q failed: %v", name, err), r, config.LogClientInfo)
+		http.Error(w, fmt.Sprintf("Failed to %s queue: %v", name, err), http.StatusBadGateway)
+		return
 	}
+	debugLog(config.Debug, "INFO", fmt.Sprintf("Queue action %q succeeded", name), r, config.LogClientInfo)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":true}`))
+}
 
-	client := http.Client{
-		Timeout: 5 * time.Second,
+// parseSlotDeletePath extracts the nzo ID from a path of the form
+// /api/queue/slot/{nzo}/delete.
+func parseSlotDeletePath(path string) (nzoID string, ok bool) {
+	rest := strings.TrimPrefix(path, "/api/queue/slot/")
+	if rest == path {
+		return "", false
+	}
+	nzoID = strings.TrimSuffix(rest, "/delete")
+	if nzoID == rest || nzoID == "" {
+		return "", false
 	}
+	return nzoID, true
+}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		debugLog(config.Debug, "ERROR", fmt.Sprintf("API request failed: %v", err), nil, false)
-		return nil, err
+// serveStatusEvents streams SABnzbd status updates to a single client as
+// Server-Sent Events, backed by the shared StatusBroker. Each event carries
+// the dashboard already rendered to HTML, so the browser can drop it
+// straight into the page instead of re-fetching /status on every tick.
+func serveStatusEvents(broker *StatusBroker, tmpl *template.Template, config Config, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		debugLog(config.Debug, "ERROR", fmt.Sprintf("API returned non-OK status: %d", resp.StatusCode), nil, false)
-		return nil, fmt.Errorf("API returned non-OK status: %d", resp.StatusCode)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	debugLog(config.Debug, "INFO", fmt.Sprintf("/events subscriber connected (total: %d)", broker.SubscriberCount()), r, config.LogClientInfo)
+
+	if status := broker.Latest(); status != nil {
+		if !writeStatusEvent(w, tmpl, status) {
+			return
+		}
+		flusher.Flush()
 	}
 
-	if config.Debug {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		// Don't log full response potentially containing sensitive data
-		debugLog(true, "DEBUG", fmt.Sprintf("API response received, status: %s, length: %d bytes",
-			resp.Status, len(bodyBytes)), nil, false)
+	ping := time.NewTicker(statusPingInterval)
+	defer ping.Stop()
 
-		// We need to recreate the response body as we've read it
-		resp.Body.Close()
-		resp.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case status := <-updates:
+			if !writeStatusEvent(w, tmpl, status) {
+				return
+			}
+			flusher.Flush()
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
 	}
+}
 
-	var status SabnzbdStatus
-	err = json.NewDecoder(resp.Body).Decode(&status)
-	if err != nil {
-		debugLog(config.Debug, "ERROR", fmt.Sprintf("Failed to decode API response: %v", err), nil, false)
-		return nil, err
+// writeStatusEvent renders the dashboard for statuses the same way /status
+// does, then writes it as an SSE "status" event, one "data:" line per line
+// of HTML as the SSE framing requires.
+func writeStatusEvent(w http.ResponseWriter, tmpl *template.Template, statuses map[string]*SabnzbdStatus) bool {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "status.html", buildDashboard(statuses)); err != nil {
+		return false
 	}
 
-	return &status, nil
+	if _, err := fmt.Fprint(w, "event: status\n"); err != nil {
+		return false
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if _, err := fmt.Fprintf(w, "data: %s\n", line); err != nil {
+			return false
+		}
+	}
+	_, err := fmt.Fprint(w, "\n")
+	return err == nil
 }
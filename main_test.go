@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -152,7 +153,8 @@ func TestFetchSabnzbdStatus(t *testing.T) {
 	}
 
 	// Test fetching status
-	status, err := fetchSabnzbdStatus(config)
+	client := NewSabnzbdClient(config, http.DefaultClient)
+	status, err := client.Status(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to fetch SABnzbd status: %v", err)
 	}
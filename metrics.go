@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics describing the SABnzbd queue and sab_monitarr's own
+// HTTP surface. Registered once at package init and updated as status is
+// polled / requests are served; the /metrics handler itself is only
+// exposed when MetricsEnabled is set.
+var (
+	queueSpeedBytesPerSecond = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sabnzbd_queue_speed_bytes_per_second",
+		Help: "Current SABnzbd download speed in bytes per second.",
+	}, []string{"instance"})
+	queueSizeLeftBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sabnzbd_queue_size_left_bytes",
+		Help: "Total bytes left to download across the SABnzbd queue.",
+	}, []string{"instance"})
+	queueTimeLeftSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sabnzbd_queue_time_left_seconds",
+		Help: "Estimated seconds left for the SABnzbd queue to finish.",
+	}, []string{"instance"})
+	queueSlots = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sabnzbd_queue_slots",
+		Help: "Number of SABnzbd queue slots, by status.",
+	}, []string{"instance", "status"})
+	queueSlotPercentage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sabnzbd_queue_slot_percentage",
+		Help: "Download completion percentage of a single queue slot.",
+	}, []string{"instance", "filename"})
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sabmon_http_requests_total",
+		Help: "Total HTTP requests served by sab_monitarr, by path and status code.",
+	}, []string{"path", "code"})
+	sabnzbdFetchErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "sabmon_sabnzbd_fetch_errors_total",
+		Help: "Total failed requests to the SABnzbd API.",
+	})
+	sabnzbdFetchDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "sabmon_sabnzbd_fetch_duration_seconds",
+		Help: "Duration of requests to the SABnzbd API, in seconds.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		queueSpeedBytesPerSecond,
+		queueSizeLeftBytes,
+		queueTimeLeftSeconds,
+		queueSlots,
+		queueSlotPercentage,
+		httpRequestsTotal,
+		sabnzbdFetchErrorsTotal,
+		sabnzbdFetchDurationSeconds,
+	)
+}
+
+// recordSabnzbdMetrics updates the queue gauges for one instance from a
+// freshly fetched status. The slot vectors for that instance are reset
+// first so slots/filenames that have left the queue don't linger as stale
+// series.
+func recordSabnzbdMetrics(instance string, status *SabnzbdStatus) {
+	if speed, err := parseSpeed(status.Queue.Speed); err == nil {
+		queueSpeedBytesPerSecond.WithLabelValues(instance).Set(speed)
+	}
+	if sizeLeft, err := parseSize(status.Queue.SizeLeft); err == nil {
+		queueSizeLeftBytes.WithLabelValues(instance).Set(sizeLeft)
+	}
+	if timeLeft, err := parseDuration(status.Queue.TimeLeft); err == nil {
+		queueTimeLeftSeconds.WithLabelValues(instance).Set(timeLeft.Seconds())
+	}
+
+	queueSlots.DeletePartialMatch(prometheus.Labels{"instance": instance})
+	queueSlotPercentage.DeletePartialMatch(prometheus.Labels{"instance": instance})
+
+	slotsByStatus := make(map[string]float64)
+	for _, slot := range status.Queue.Slots {
+		slotsByStatus[slot.Status]++
+		if percentage, err := parseSize(slot.Percentage); err == nil {
+			queueSlotPercentage.WithLabelValues(instance, slot.Filename).Set(percentage)
+		}
+	}
+	for slotStatus, count := range slotsByStatus {
+		queueSlots.WithLabelValues(instance, slotStatus).Set(count)
+	}
+}
+
+// recordSabnzbdFetch records a single SABnzbd API call's duration and
+// whether it failed.
+func recordSabnzbdFetch(duration time.Duration, err error) {
+	sabnzbdFetchDurationSeconds.Observe(duration.Seconds())
+	if err != nil {
+		sabnzbdFetchErrorsTotal.Inc()
+	}
+}
+
+// metricsMiddleware records sabmon_http_requests_total for every request
+// that passes through it, regardless of whether /metrics is exposed.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusCodeRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		httpRequestsTotal.WithLabelValues(metricsRoutePath(r.URL.Path), strconv.Itoa(recorder.statusCode)).Inc()
+	})
+}
+
+// metricsRoutePath normalizes a request path to its route template before
+// it's used as a metric label, so variable path segments (like the nzo ID
+// in a slot delete) don't turn into unbounded label cardinality.
+func metricsRoutePath(path string) string {
+	if _, ok := parseSlotDeletePath(path); ok {
+		return "/api/queue/slot/delete"
+	}
+	return path
+}
+
+// statusCodeRecorder captures the status code written by a downstream
+// handler so middleware can observe it after the fact.
+type statusCodeRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusCodeRecorder) WriteHeader(code int) {
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// metricsHandler exposes the registered metrics in the Prometheus exposition
+// format.
+func metricsHandler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps an http.Handler to add cross-cutting behavior (logging,
+// compression, rate limiting, ...) before or after the wrapped handler runs.
+type Middleware func(http.Handler) http.Handler
+
+// MiddlewareChain is an ordered list of Middleware, applied outermost-first:
+// Chain(a, b).Then(h) serves a request through a, then b, then h.
+type MiddlewareChain struct {
+	middlewares []Middleware
+}
+
+// Chain builds a MiddlewareChain from the given middlewares, in the order
+// they should run.
+func Chain(mws ...Middleware) MiddlewareChain {
+	return MiddlewareChain{middlewares: mws}
+}
+
+// Then wraps h with every middleware in the chain.
+func (c MiddlewareChain) Then(h http.Handler) http.Handler {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	return h
+}
+
+// RequestIDHeader is the header used to propagate a request's ID to the
+// client and into log lines.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware assigns a short random request ID to any request that
+// doesn't already carry one (e.g. from a reverse proxy), and echoes it back
+// in the response so it can be correlated with logs.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(RequestIDHeader) == "" {
+			r.Header.Set(RequestIDHeader, newRequestID())
+		}
+		w.Header().Set(RequestIDHeader, r.Header.Get(RequestIDHeader))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RecoverMiddleware turns a panic anywhere downstream into a 500 response
+// instead of taking down the whole server, logging the stack trace first.
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[ERROR] panic handling %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// gzipResponseWriter wraps an http.ResponseWriter so writes go through a
+// gzip.Writer instead of straight to the client.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.gz.Write(b)
+}
+
+// GzipMiddleware compresses the response body when the client advertises
+// support for it via Accept-Encoding.
+func GzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		gz := gzip.NewWriter(w)
+		defer func() {
+			if rec := recover(); rec != nil {
+				// A downstream panic means nothing should be flushed to
+				// the client: closing gz here would write the gzip
+				// header/trailer and implicitly commit a 200 before
+				// RecoverMiddleware's own deferred recover() gets a
+				// chance to write a clean 500. Undo the headers we set
+				// above too, so that eventual error response isn't
+				// mislabeled as gzip, then keep unwinding so the panic
+				// reaches RecoverMiddleware.
+				w.Header().Del("Content-Encoding")
+				panic(rec)
+			}
+			gz.Close()
+		}()
+
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+	})
+}
+
+// rateLimiter is a simple per-client-IP token bucket: Capacity tokens,
+// refilled at RefillPerSecond, checked on every Allow call.
+//
+// Known limitation: buckets is never swept, so it holds one entry per
+// distinct client IP seen for the life of the process. Fine for the
+// small/trusted deployments this project targets; revisit with a TTL
+// sweep if it's ever exposed to a large or adversarial set of clients.
+type rateLimiter struct {
+	mu           sync.Mutex
+	buckets      map[string]*tokenBucket
+	capacity     float64
+	refillPerSec float64
+}
+
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newRateLimiter(capacity, refillPerSecond float64) *rateLimiter {
+	return &rateLimiter{
+		buckets:      make(map[string]*tokenBucket),
+		capacity:     capacity,
+		refillPerSec: refillPerSecond,
+	}
+}
+
+// Allow reports whether a request from key (typically a client IP) may
+// proceed, consuming one token if so.
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.capacity, lastRefill: time.Now()}
+		l.buckets[key] = bucket
+	}
+
+	elapsed := time.Since(bucket.lastRefill).Seconds()
+	bucket.tokens = minFloat(l.capacity, bucket.tokens+elapsed*l.refillPerSec)
+	bucket.lastRefill = time.Now()
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// RateLimitMiddleware limits requests per client IP to the given requests
+// per second (with a matching burst capacity), but only for the given set
+// of paths; every other path passes through untouched.
+//
+// trustProxyHeaders controls which IP the limit is keyed on. A client can
+// set X-Forwarded-For to whatever it wants, so trusting it here without a
+// proxy in front that overwrites/strips it lets a caller reset its own
+// bucket on every request by varying the header. Leave this false unless
+// sab_monitarr is deployed behind a reverse proxy that can be trusted to
+// set X-Forwarded-For itself.
+func RateLimitMiddleware(requestsPerSecond float64, burst int, paths map[string]bool, trustProxyHeaders bool) Middleware {
+	limiter := newRateLimiter(float64(burst), requestsPerSecond)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !paths[metricsRoutePath(r.URL.Path)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := remoteAddrIP(r)
+			if trustProxyHeaders {
+				key = getClientIP(r)
+			}
+			if !limiter.Allow(key) {
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitedPaths builds the path set RateLimitMiddleware should guard:
+// /status plus every state-changing queue control endpoint. Paths are
+// matched after metricsRoutePath normalization, so the prefix-routed slot
+// delete endpoint (/api/queue/slot/{nzo}/delete) is covered under its
+// route template rather than needing one entry per nzo ID.
+func rateLimitedPaths() map[string]bool {
+	return map[string]bool{
+		"/status":                true,
+		"/api/queue/pause":       true,
+		"/api/queue/resume":      true,
+		"/api/queue/speedlimit":  true,
+		"/api/queue/slot/delete": true,
+	}
+}
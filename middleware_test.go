@@ -0,0 +1,236 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChainAppliesMiddlewareOutermostFirst(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(mark("a"), mark("b")).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"a", "b", "handler"}
+	for i, name := range want {
+		if i >= len(order) || order[i] != name {
+			t.Fatalf("Expected call order %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRequestIDMiddlewareAssignsAndPropagatesID(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIDHeader)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if seen == "" {
+		t.Fatal("Expected a request ID to be assigned")
+	}
+	if rec.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected response header to echo the assigned request ID %q, got %q", seen, rec.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestIDMiddlewarePreservesExistingID(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIDHeader)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream-id")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != "upstream-id" {
+		t.Errorf("Expected existing request ID to be preserved, got %q", seen)
+	}
+}
+
+func TestRecoverMiddlewareTurnsPanicIntoInternalServerError(t *testing.T) {
+	handler := RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after a recovered panic, got %d", rec.Code)
+	}
+}
+
+func TestRecoverMiddlewareStillWorksWhenGzipIsInnerMiddleware(t *testing.T) {
+	handler := Chain(RecoverMiddleware, RequestIDMiddleware, GzipMiddleware).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after a panic behind GzipMiddleware, got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Encoding") == "gzip" {
+		t.Error("Expected a recovered panic's response to not be marked as gzip-encoded")
+	}
+	if rec.Body.String() != "Internal server error\n" {
+		t.Errorf("Expected a clean plaintext error body, got %q", rec.Body.String())
+	}
+}
+
+func TestGzipMiddlewareCompressesWhenAccepted(t *testing.T) {
+	handler := GzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected Content-Encoding: gzip, got %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Expected a valid gzip body, got error: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress body: %v", err)
+	}
+	if string(body) != "hello world" {
+		t.Errorf("Expected decompressed body %q, got %q", "hello world", string(body))
+	}
+}
+
+func TestGzipMiddlewareSkipsWithoutAcceptEncoding(t *testing.T) {
+	handler := GzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Header().Get("Content-Encoding") == "gzip" {
+		t.Error("Expected no compression when the client doesn't accept gzip")
+	}
+	if rec.Body.String() != "hello world" {
+		t.Errorf("Expected uncompressed body %q, got %q", "hello world", rec.Body.String())
+	}
+}
+
+func TestRateLimiterAllowsBurstThenBlocks(t *testing.T) {
+	limiter := newRateLimiter(2, 0)
+
+	if !limiter.Allow("client") || !limiter.Allow("client") {
+		t.Fatal("Expected the first two requests within the burst to be allowed")
+	}
+	if limiter.Allow("client") {
+		t.Error("Expected a third request to be blocked once the burst is exhausted")
+	}
+}
+
+func TestRateLimitMiddlewareOnlyGuardsConfiguredPaths(t *testing.T) {
+	mw := RateLimitMiddleware(0, 1, map[string]bool{"/status": true}, false)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to /status to be allowed, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the second request to /status to be rate limited, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected an unguarded path to bypass the rate limiter, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareIgnoresXForwardedForByDefault(t *testing.T) {
+	mw := RateLimitMiddleware(0, 1, map[string]bool{"/status": true}, false)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newReq := func(forwardedFor string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.RemoteAddr = "10.0.0.1:5555"
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq("1.1.1.1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to be allowed, got %d", rec.Code)
+	}
+
+	// A spoofed X-Forwarded-For must not reset the bucket: with
+	// trustProxyHeaders off, both requests key on the same RemoteAddr.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq("2.2.2.2"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected a spoofed X-Forwarded-For to still be rate limited, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareTrustsXForwardedForWhenConfigured(t *testing.T) {
+	mw := RateLimitMiddleware(0, 1, map[string]bool{"/status": true}, true)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newReq := func(forwardedFor string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.RemoteAddr = "10.0.0.1:5555"
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq("1.1.1.1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to be allowed, got %d", rec.Code)
+	}
+
+	// With trustProxyHeaders on, a different X-Forwarded-For is a
+	// different bucket, behind a reverse proxy that's trusted to set it.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq("2.2.2.2"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected a different trusted client IP to get its own bucket, got %d", rec.Code)
+	}
+}
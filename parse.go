@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SABnzbd reports speed, size and time-left as pre-formatted strings meant
+// for display ("2.5 MB/s", "500 MB", "00:03:20"). These helpers turn them
+// back into numbers so they can be exported as Prometheus gauges.
+
+// unitMultipliers maps the size/speed unit suffixes SABnzbd uses to a byte
+// multiplier. SABnzbd reports decimal units (1 KB = 1000 B), not binary.
+var unitMultipliers = map[string]float64{
+	"B":  1,
+	"KB": 1000,
+	"MB": 1000 * 1000,
+	"GB": 1000 * 1000 * 1000,
+	"TB": 1000 * 1000 * 1000 * 1000,
+}
+
+// parseSpeed parses a SABnzbd speed string like "2.5 MB/s" into bytes per
+// second. An empty or "0" speed parses as 0 with no error.
+func parseSpeed(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "/s")
+	return parseSize(s)
+}
+
+// parseSize parses a SABnzbd size string like "500 MB" into bytes.
+func parseSize(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		// No unit suffix means raw bytes.
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse size %q: %v", s, err)
+		}
+		return value, nil
+	case 2:
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse size %q: %v", s, err)
+		}
+		multiplier, ok := unitMultipliers[strings.ToUpper(fields[1])]
+		if !ok {
+			return 0, fmt.Errorf("could not parse size %q: unknown unit %q", s, fields[1])
+		}
+		return value * multiplier, nil
+	default:
+		return 0, fmt.Errorf("could not parse size %q: unexpected format", s)
+	}
+}
+
+// parseDuration parses a SABnzbd "HH:MM:SS" time-left string into seconds.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("could not parse duration %q: expected HH:MM:SS", s)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("could not parse duration %q: %v", s, err)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("could not parse duration %q: %v", s, err)
+	}
+	seconds, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, fmt.Errorf("could not parse duration %q: %v", s, err)
+	}
+
+	total := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	return total, nil
+}
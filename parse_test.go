@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func TestParseSpeed(t *testing.T) {
+	cases := []struct {
+		in   string
+		want float64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"2.5 MB/s", 2.5 * 1000 * 1000},
+		{"500 KB/s", 500 * 1000},
+		{"1 GB/s", 1000 * 1000 * 1000},
+	}
+
+	for _, c := range cases {
+		got, err := parseSpeed(c.in)
+		if err != nil {
+			t.Errorf("parseSpeed(%q) returned error: %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseSpeed(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseSpeedInvalid(t *testing.T) {
+	if _, err := parseSpeed("lots/s"); err == nil {
+		t.Error("Expected error for non-numeric speed")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want float64
+	}{
+		{"", 0},
+		{"500 MB", 500 * 1000 * 1000},
+		{"1.5 GB", 1.5 * 1000 * 1000 * 1000},
+		{"1024", 1024},
+	}
+
+	for _, c := range cases {
+		got, err := parseSize(c.in)
+		if err != nil {
+			t.Errorf("parseSize(%q) returned error: %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseSize(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	if _, err := parseSize("big MB"); err == nil {
+		t.Error("Expected error for non-numeric size")
+	}
+	if _, err := parseSize("500 XB"); err == nil {
+		t.Error("Expected error for unknown unit")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int // seconds
+	}{
+		{"", 0},
+		{"00:03:20", 200},
+		{"01:00:00", 3600},
+		{"00:00:01", 1},
+	}
+
+	for _, c := range cases {
+		got, err := parseDuration(c.in)
+		if err != nil {
+			t.Errorf("parseDuration(%q) returned error: %v", c.in, err)
+			continue
+		}
+		if int(got.Seconds()) != c.want {
+			t.Errorf("parseDuration(%q) = %v seconds, want %v", c.in, got.Seconds(), c.want)
+		}
+	}
+}
+
+func TestParseDurationInvalid(t *testing.T) {
+	if _, err := parseDuration("3:20"); err == nil {
+		t.Error("Expected error for malformed duration")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SabnzbdClient talks to a single SABnzbd instance's JSON API. It wraps the
+// base URL and API key so callers don't have to thread them through every
+// request. The http.Client is supplied by the caller so every instance can
+// share one connection pool instead of each opening its own.
+type SabnzbdClient struct {
+	baseURL string
+	apiKey  string
+	debug   bool
+	http    *http.Client
+}
+
+// NewSabnzbdClient builds a client for the single SABnzbd instance described
+// by the deprecated Config.SabnzbdURL/SabnzbdAPIKey fields.
+func NewSabnzbdClient(config Config, httpClient *http.Client) *SabnzbdClient {
+	return &SabnzbdClient{
+		baseURL: config.SabnzbdURL,
+		apiKey:  config.SabnzbdAPIKey,
+		debug:   config.Debug,
+		http:    httpClient,
+	}
+}
+
+// NewSabnzbdClientForInstance builds a client for one entry of
+// Config.Instances. httpClient is typically shared across every instance so
+// they all draw from the same connection pool.
+func NewSabnzbdClientForInstance(instance InstanceConfig, debug bool, httpClient *http.Client) *SabnzbdClient {
+	return &SabnzbdClient{
+		baseURL: instance.URL,
+		apiKey:  instance.APIKey,
+		debug:   debug,
+		http:    httpClient,
+	}
+}
+
+// fetchAll queries every client in parallel over a shared timeout budget,
+// tolerating individual instance failures: an instance that errors is
+// simply omitted from the result map rather than failing the whole call.
+// Canceling ctx cancels every in-flight request.
+func fetchAll(ctx context.Context, clients map[string]*SabnzbdClient) map[string]*SabnzbdStatus {
+	type result struct {
+		name   string
+		status *SabnzbdStatus
+		err    error
+	}
+
+	results := make(chan result, len(clients))
+	for name, client := range clients {
+		name, client := name, client
+		go func() {
+			start := time.Now()
+			status, err := client.Status(ctx)
+			recordSabnzbdFetch(time.Since(start), err)
+			results <- result{name: name, status: status, err: err}
+		}()
+	}
+
+	statuses := make(map[string]*SabnzbdStatus, len(clients))
+	for range clients {
+		r := <-results
+		if r.err != nil {
+			debugLog(true, "ERROR", fmt.Sprintf("Failed to fetch status for instance %q: %v", r.name, r.err), nil, false)
+			continue
+		}
+		statuses[r.name] = r.status
+	}
+	return statuses
+}
+
+// sabnzbdAPIResponse is the generic envelope SABnzbd returns for mode calls
+// that don't return a queue (pause, resume, etc.): {"status": true}.
+type sabnzbdAPIResponse struct {
+	Status bool   `json:"status"`
+	Error  string `json:"error"`
+}
+
+// call issues a GET request against the SABnzbd API for the given mode with
+// extra query parameters, and returns the raw response body. ctx governs
+// cancellation of the underlying HTTP request.
+func (c *SabnzbdClient) call(ctx context.Context, mode string, params url.Values) ([]byte, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("output", "json")
+	params.Set("apikey", c.apiKey)
+	params.Set("mode", mode)
+
+	requestURL := fmt.Sprintf("%s/api?%s", c.baseURL, params.Encode())
+
+	if c.debug {
+		safeURL := strings.Replace(requestURL, c.apiKey, "[REDACTED]", 1)
+		debugLog(true, "DEBUG", fmt.Sprintf("Requesting SABnzbd API: %s", safeURL), nil, false)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		debugLog(c.debug, "ERROR", fmt.Sprintf("API request failed: %v", err), nil, false)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		debugLog(c.debug, "ERROR", fmt.Sprintf("API returned non-OK status: %d", resp.StatusCode), nil, false)
+		return nil, fmt.Errorf("API returned non-OK status: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// callOK issues a mode call that's expected to return the generic
+// {"status": true} envelope, and turns a false status into an error.
+func (c *SabnzbdClient) callOK(ctx context.Context, mode string, params url.Values) error {
+	body, err := c.call(ctx, mode, params)
+	if err != nil {
+		return err
+	}
+
+	var result sabnzbdAPIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("could not decode SABnzbd response: %v", err)
+	}
+	if !result.Status {
+		if result.Error != "" {
+			return fmt.Errorf("SABnzbd rejected request: %s", result.Error)
+		}
+		return fmt.Errorf("SABnzbd rejected request")
+	}
+	return nil
+}
+
+// Status fetches the current queue status (mode=queue).
+func (c *SabnzbdClient) Status(ctx context.Context) (*SabnzbdStatus, error) {
+	body, err := c.call(ctx, "queue", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.debug {
+		debugLog(true, "DEBUG", fmt.Sprintf("API response received, length: %d bytes", len(body)), nil, false)
+	}
+
+	var status SabnzbdStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		debugLog(c.debug, "ERROR", fmt.Sprintf("Failed to decode API response: %v", err), nil, false)
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Pause pauses the whole queue (mode=pause).
+func (c *SabnzbdClient) Pause(ctx context.Context) error {
+	return c.callOK(ctx, "pause", nil)
+}
+
+// Resume resumes the whole queue (mode=resume).
+func (c *SabnzbdClient) Resume(ctx context.Context) error {
+	return c.callOK(ctx, "resume", nil)
+}
+
+// SetSpeedLimit sets the download speed limit as a percentage of the
+// configured maximum (mode=config&name=speedlimit).
+func (c *SabnzbdClient) SetSpeedLimit(ctx context.Context, limit string) error {
+	params := url.Values{"value": {limit}}
+	return c.callOK(ctx, "config", mergeValues(params, url.Values{"name": {"speedlimit"}}))
+}
+
+// DeleteSlot removes a single queued item by its nzo ID (mode=queue&name=delete).
+func (c *SabnzbdClient) DeleteSlot(ctx context.Context, nzoID string) error {
+	params := url.Values{"value": {nzoID}}
+	return c.callOK(ctx, "queue", mergeValues(params, url.Values{"name": {"delete"}}))
+}
+
+func mergeValues(dst, src url.Values) url.Values {
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// resolveInstance picks which configured instance a request targets, via an
+// "instance" form/query value. If there's exactly one configured instance,
+// it's used as the default so single-instance deployments don't need to
+// pass the parameter at all.
+func resolveInstance(clients map[string]*SabnzbdClient, r *http.Request) (*SabnzbdClient, string, error) {
+	name := r.FormValue("instance")
+	if name == "" {
+		if len(clients) == 1 {
+			for onlyName := range clients {
+				return clients[onlyName], onlyName, nil
+			}
+		}
+		return nil, "", fmt.Errorf("an \"instance\" parameter is required when more than one instance is configured")
+	}
+
+	client, ok := clients[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown instance %q", name)
+	}
+	return client, name, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func newFormRequest(t *testing.T, instance string) *http.Request {
+	t.Helper()
+	r, err := http.NewRequest(http.MethodPost, "/api/queue/pause", nil)
+	if err != nil {
+		t.Fatalf("Failed to build test request: %v", err)
+	}
+	if instance != "" {
+		r.URL.RawQuery = url.Values{"instance": {instance}}.Encode()
+	}
+	return r
+}
+
+func TestResolveInstanceDefaultsWhenOnlyOneConfigured(t *testing.T) {
+	home := NewSabnzbdClientForInstance(InstanceConfig{Name: "home", URL: "http://home", APIKey: "key"}, false, http.DefaultClient)
+	clients := map[string]*SabnzbdClient{"home": home}
+
+	client, name, err := resolveInstance(clients, newFormRequest(t, ""))
+	if err != nil {
+		t.Fatalf("Expected no error with a single configured instance, got %v", err)
+	}
+	if name != "home" || client != home {
+		t.Errorf("Expected the sole instance 'home' to be selected, got %q", name)
+	}
+}
+
+func TestResolveInstanceRequiresNameWithMultipleInstances(t *testing.T) {
+	clients := map[string]*SabnzbdClient{
+		"home":    NewSabnzbdClientForInstance(InstanceConfig{Name: "home"}, false, http.DefaultClient),
+		"seedbox": NewSabnzbdClientForInstance(InstanceConfig{Name: "seedbox"}, false, http.DefaultClient),
+	}
+
+	if _, _, err := resolveInstance(clients, newFormRequest(t, "")); err == nil {
+		t.Error("Expected an error when no instance is specified and more than one is configured")
+	}
+
+	client, name, err := resolveInstance(clients, newFormRequest(t, "seedbox"))
+	if err != nil {
+		t.Fatalf("Expected no error for a known instance name, got %v", err)
+	}
+	if name != "seedbox" || client != clients["seedbox"] {
+		t.Errorf("Expected the 'seedbox' instance to be selected, got %q", name)
+	}
+}
+
+func TestResolveInstanceUnknownName(t *testing.T) {
+	clients := map[string]*SabnzbdClient{"home": NewSabnzbdClientForInstance(InstanceConfig{Name: "home"}, false, http.DefaultClient)}
+
+	if _, _, err := resolveInstance(clients, newFormRequest(t, "nope")); err == nil {
+		t.Error("Expected an error for an unknown instance name")
+	}
+}
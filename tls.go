@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// selfSignedDNSName is the fixed Subject Alternative Name baked into
+// auto-generated certificates. It does not need to resolve; browsers just
+// need a SAN to match against when the user connects via the LAN IP or
+// a local hostname with the TLS verification relaxed/accepted once.
+const selfSignedDNSName = "sab_monitarr"
+
+// autoCertValidity is deliberately long so that users who click through the
+// browser warning once don't have to do it again after every restart.
+const autoCertValidity = 10 * 365 * 24 * time.Hour
+
+// resolveTLSFiles figures out which cert/key pair (if any) the server should
+// use. If TLSCertFile/TLSKeyFile are set they're used as-is. Otherwise, if
+// AutoTLS is enabled, a self-signed cert is generated on first run and
+// reused on every subsequent run.
+func resolveTLSFiles(config Config) (certFile, keyFile string, useTLS bool, err error) {
+	if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+		return config.TLSCertFile, config.TLSKeyFile, true, nil
+	}
+
+	if !config.AutoTLS {
+		return "", "", false, nil
+	}
+
+	certFile, keyFile, err = ensureSelfSignedCert()
+	if err != nil {
+		return "", "", false, err
+	}
+	return certFile, keyFile, true, nil
+}
+
+// ensureSelfSignedCert returns the paths to a self-signed certificate and
+// key under the user's config directory, generating them on first use and
+// reusing them on subsequent restarts.
+func ensureSelfSignedCert() (certFile, keyFile string, err error) {
+	dir, err := tlsConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("could not create TLS config directory: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	if fileExists(certFile) && fileExists(keyFile) {
+		return certFile, keyFile, nil
+	}
+
+	log.Printf("Generating self-signed TLS certificate at %s", certFile)
+	if err := generateSelfSignedCert(certFile, keyFile); err != nil {
+		return "", "", fmt.Errorf("could not generate self-signed certificate: %v", err)
+	}
+
+	return certFile, keyFile, nil
+}
+
+// tlsConfigDir returns the directory where persisted TLS material is stored.
+func tlsConfigDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %v", err)
+	}
+	return filepath.Join(configDir, "sab_monitarr"), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// generateSelfSignedCert creates a new ECDSA key pair and a self-signed
+// certificate for selfSignedDNSName, writing both as PEM files.
+func generateSelfSignedCert(certFile, keyFile string) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   selfSignedDNSName,
+			Organization: []string{"sab_monitarr"},
+		},
+		DNSNames:              []string{selfSignedDNSName, "localhost"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(autoCertValidity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return err
+	}
+
+	certOut, err := os.OpenFile(certFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer certOut.Close()
+	if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes}); err != nil {
+		return err
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	keyOut, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer keyOut.Close()
+	return pem.Encode(keyOut, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+}
+
+// serveRedirect runs an HTTP listener on RedirectPort that 301s every
+// request over to the HTTPS port, so plain http://host:5959 links still work.
+func serveRedirect(config Config) {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if idx := hostWithoutPort(host); idx != "" {
+			host = idx
+		}
+		target := fmt.Sprintf("https://%s:%s%s", host, AppPort, r.URL.RequestURI())
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+
+	log.Printf("HTTP->HTTPS redirect listening on :%s", config.RedirectPort)
+	if err := http.ListenAndServe(":"+config.RedirectPort, redirect); err != nil {
+		log.Printf("Redirect listener stopped: %v", err)
+	}
+}
+
+func hostWithoutPort(host string) string {
+	for i := len(host) - 1; i >= 0; i-- {
+		if host[i] == ':' {
+			return host[:i]
+		}
+		if host[i] == ']' {
+			break
+		}
+	}
+	return host
+}
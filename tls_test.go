@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveTLSFilesUsesConfiguredCertAndKey(t *testing.T) {
+	config := Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}
+
+	certFile, keyFile, useTLS, err := resolveTLSFiles(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !useTLS {
+		t.Error("Expected useTLS to be true when a cert/key pair is configured")
+	}
+	if certFile != "cert.pem" || keyFile != "key.pem" {
+		t.Errorf("Expected the configured cert/key to be returned as-is, got %q, %q", certFile, keyFile)
+	}
+}
+
+func TestResolveTLSFilesDisabledWithoutAutoTLS(t *testing.T) {
+	_, _, useTLS, err := resolveTLSFiles(Config{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if useTLS {
+		t.Error("Expected useTLS to be false when no cert/key is configured and AutoTLS is off")
+	}
+}
+
+func TestEnsureSelfSignedCertGeneratesAndReusesOnRestart(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	certFile, keyFile, err := ensureSelfSignedCert()
+	if err != nil {
+		t.Fatalf("Expected cert generation to succeed, got %v", err)
+	}
+	firstCert, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("Expected the generated cert file to exist: %v", err)
+	}
+
+	// A second call simulates a restart: it should reuse the persisted
+	// cert/key rather than generating a new pair.
+	certFile2, keyFile2, err := ensureSelfSignedCert()
+	if err != nil {
+		t.Fatalf("Expected the second call to succeed, got %v", err)
+	}
+	if certFile2 != certFile || keyFile2 != keyFile {
+		t.Errorf("Expected the same cert/key paths on reuse, got %q/%q then %q/%q", certFile, keyFile, certFile2, keyFile2)
+	}
+
+	secondCert, err := os.ReadFile(certFile2)
+	if err != nil {
+		t.Fatalf("Expected the reused cert file to exist: %v", err)
+	}
+	if string(firstCert) != string(secondCert) {
+		t.Error("Expected the certificate to be reused unchanged across restarts, not regenerated")
+	}
+}
+
+func TestGenerateSelfSignedCertProducesAValidCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	if err := generateSelfSignedCert(certFile, keyFile); err != nil {
+		t.Fatalf("Expected cert generation to succeed, got %v", err)
+	}
+
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("Expected the cert file to exist: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("Expected a CERTIFICATE PEM block, got %+v", block)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Expected a parseable certificate, got %v", err)
+	}
+
+	foundLocalhost := false
+	for _, name := range cert.DNSNames {
+		if name == "localhost" {
+			foundLocalhost = true
+		}
+	}
+	if !foundLocalhost {
+		t.Errorf("Expected \"localhost\" among the certificate's DNS names, got %v", cert.DNSNames)
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatalf("Expected the key file to exist: %v", err)
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil || keyBlock.Type != "EC PRIVATE KEY" {
+		t.Fatalf("Expected an EC PRIVATE KEY PEM block, got %+v", keyBlock)
+	}
+}
+
+func TestHostWithoutPort(t *testing.T) {
+	cases := []struct {
+		host string
+		want string
+	}{
+		{"example.com:5959", "example.com"},
+		{"example.com", "example.com"},
+		{"[::1]:5959", "[::1]"},
+	}
+
+	for _, c := range cases {
+		if got := hostWithoutPort(c.host); got != c.want {
+			t.Errorf("hostWithoutPort(%q) = %q, want %q", c.host, got, c.want)
+		}
+	}
+}